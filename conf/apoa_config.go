@@ -0,0 +1,52 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package conf
+
+// APoaConfig is the consensus engine configuration parameters for proof-of-authority.
+type APoaConfig struct {
+	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
+	Epoch  uint64 `json:"epoch"`  // Number of blocks after which to checkpoint and reset the pending votes
+
+	// CheckpointInterval, when non-zero and smaller than Epoch, forces a full
+	// snapshot to be flushed to disk more often than every epoch, bounding
+	// how many deltas must be replayed to reconstruct a snapshot on read.
+	CheckpointInterval uint64 `json:"checkpointInterval"`
+
+	// RetentionBlocks is the size of the window, in blocks, of persisted
+	// snapshots the background pruner keeps on disk. A value of 0 disables
+	// pruning entirely.
+	RetentionBlocks uint64 `json:"retentionBlocks"`
+
+	// SchedulerKind selects the Scheduler used to order signers within an
+	// epoch (e.g. "round-robin", "shuffled-epoch"). Unset or unrecognized
+	// values fall back to the original round-robin order.
+	SchedulerKind string `json:"schedulerKind"`
+
+	// InactivityThreshold is the number of blocks a signer may go without
+	// successfully sealing before AutoKickEnabled considers it offline.
+	InactivityThreshold uint64 `json:"inactivityThreshold"`
+
+	// AutoKickEnabled, when true, makes the engine automatically stuff a
+	// deauthorization vote into its own prepared blocks against the first
+	// signer found to be inactive beyond InactivityThreshold.
+	AutoKickEnabled bool `json:"autoKickEnabled"`
+
+	// VoteTTL, when non-zero, expires any vote older than VoteTTL blocks
+	// independently of epoch resets. A value of 0 preserves the legacy
+	// behavior of only clearing votes at epoch boundaries.
+	VoteTTL uint64 `json:"voteTTL"`
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"github.com/amazechain/amc/common/block"
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/internal/consensus"
+	"github.com/amazechain/amc/internal/rpc"
+)
+
+// API is a user facing RPC API to allow controlling the signer and voting
+// mechanisms of the proof-of-authority scheme.
+type API struct {
+	chain consensus.ChainHeaderReader
+	apoa  *Engine
+}
+
+// GetSnapshot retrieves the state snapshot at a given block.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header block.IHeader
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return its snapshot
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.apoa.snapshot(api.chain, header.Number().Uint64(), header.Hash(), nil)
+}
+
+// GetSnapshotAtHash retrieves the state snapshot at a given block.
+func (api *API) GetSnapshotAtHash(hash types.Hash) (*Snapshot, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.apoa.snapshot(api.chain, header.Number().Uint64(), header.Hash(), nil)
+}
+
+// GetSigners retrieves the list of authorized signers at the specified block.
+func (api *API) GetSigners(number *rpc.BlockNumber) ([]types.Address, error) {
+	var header block.IHeader
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.apoa.snapshot(api.chain, header.Number().Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// GetSignersAtHash retrieves the list of authorized signers at the specified block.
+func (api *API) GetSignersAtHash(hash types.Hash) ([]types.Address, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.apoa.snapshot(api.chain, header.Number().Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// Proposals returns the current proposals the node tries to uphold and vote on.
+func (api *API) Proposals() map[types.Address]bool {
+	api.apoa.lock.RLock()
+	defer api.apoa.lock.RUnlock()
+
+	proposals := make(map[types.Address]bool)
+	for address, auth := range api.apoa.proposals {
+		proposals[address] = auth
+	}
+	return proposals
+}
+
+// Propose injects a new authorization proposal that the signer will attempt to
+// push through.
+func (api *API) Propose(address types.Address, auth bool) {
+	api.apoa.lock.Lock()
+	defer api.apoa.lock.Unlock()
+
+	api.apoa.proposals[address] = auth
+}
+
+// Discard drops a currently running proposal, stopping the signer from casting
+// further votes (either for or against) the address.
+func (api *API) Discard(address types.Address) {
+	api.apoa.lock.Lock()
+	defer api.apoa.lock.Unlock()
+
+	delete(api.apoa.proposals, address)
+}
+
+// StoreMetrics returns cache hit/miss, write, prune and store-size counters
+// for the engine's snapshot store, useful for diagnosing sync behaviour.
+func (api *API) StoreMetrics() storeMetrics {
+	return api.apoa.store.Metrics()
+}
+
+// GetSignerLiveness returns, for every authorized signer at the given block,
+// the block number of their most recent successful seal. Signers with no
+// recorded seal are omitted. Operators can use this to see which signers are
+// at risk of being auto-kicked.
+func (api *API) GetSignerLiveness(number *rpc.BlockNumber) (map[types.Address]uint64, error) {
+	var header block.IHeader
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.apoa.snapshot(api.chain, header.Number().Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	liveness := make(map[types.Address]uint64, len(snap.LastSigned))
+	for addr := range snap.Signers {
+		if last, ok := snap.LastSigned[addr]; ok {
+			liveness[addr] = last
+		}
+	}
+	return liveness, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/amazechain/amc/common/types"
+)
+
+// SnapshotDelta is the change a single header application makes to a
+// Snapshot. Between checkpoints we persist these instead of a full snapshot,
+// since a single header only ever touches a handful of map entries.
+type SnapshotDelta struct {
+	Number    uint64
+	Hash      types.Hash
+	EpochHash types.Hash // Mirrors Snapshot.EpochHash, which can change on this same header if it's an epoch boundary
+
+	AddedSigners   []types.Address
+	RemovedSigners []types.Address
+
+	RecentEvicted []uint64
+	RecentAdded   map[uint64]types.Address
+
+	VotesAdded   []*Vote
+	VotesRemoved []*Vote
+
+	// TallyUpdated holds the new Tally for every address whose tally changed
+	// or was newly created. TallyRemoved lists addresses whose tally was
+	// dropped entirely. These are kept separate, rather than a single
+	// map[types.Address]*Tally with nil meaning "removed", because gob
+	// cannot encode a nil pointer as a map value.
+	TallyUpdated map[types.Address]Tally
+	TallyRemoved []types.Address
+}
+
+// diffSnapshot computes the SnapshotDelta that turns prev into next. It
+// assumes next was produced by applying exactly one header on top of prev.
+func diffSnapshot(prev, next *Snapshot) *SnapshotDelta {
+	d := &SnapshotDelta{
+		Number:      next.Number,
+		Hash:        next.Hash,
+		EpochHash:   next.EpochHash,
+		RecentAdded: make(map[uint64]types.Address),
+	}
+	for signer := range next.Signers {
+		if _, ok := prev.Signers[signer]; !ok {
+			d.AddedSigners = append(d.AddedSigners, signer)
+		}
+	}
+	for signer := range prev.Signers {
+		if _, ok := next.Signers[signer]; !ok {
+			d.RemovedSigners = append(d.RemovedSigners, signer)
+		}
+	}
+	for number, signer := range next.Recents {
+		if prev.Recents[number] != signer {
+			d.RecentAdded[number] = signer
+		}
+	}
+	for number := range prev.Recents {
+		if _, ok := next.Recents[number]; !ok {
+			d.RecentEvicted = append(d.RecentEvicted, number)
+		}
+	}
+
+	prevVotes := make(map[Vote]bool, len(prev.Votes))
+	for _, v := range prev.Votes {
+		prevVotes[*v] = true
+	}
+	nextVotes := make(map[Vote]bool, len(next.Votes))
+	for _, v := range next.Votes {
+		nextVotes[*v] = true
+		if !prevVotes[*v] {
+			d.VotesAdded = append(d.VotesAdded, v)
+		}
+	}
+	for _, v := range prev.Votes {
+		if !nextVotes[*v] {
+			d.VotesRemoved = append(d.VotesRemoved, v)
+		}
+	}
+
+	d.TallyUpdated = make(map[types.Address]Tally)
+	for address, tally := range next.Tally {
+		if old, ok := prev.Tally[address]; !ok || old != tally {
+			d.TallyUpdated[address] = tally
+		}
+	}
+	for address := range prev.Tally {
+		if _, ok := next.Tally[address]; !ok {
+			d.TallyRemoved = append(d.TallyRemoved, address)
+		}
+	}
+	return d
+}
+
+// applyDelta reconstructs the successor snapshot by applying d on top of s.
+func (s *Snapshot) applyDelta(d *SnapshotDelta) *Snapshot {
+	next := s.copy()
+	next.Votes = append([]*Vote{}, s.Votes...)
+
+	for _, signer := range d.AddedSigners {
+		next.Signers[signer] = struct{}{}
+	}
+	for _, signer := range d.RemovedSigners {
+		delete(next.Signers, signer)
+	}
+	// apply records a seal in LastSigned exactly when it adds it to Recents,
+	// so the added recents also carry the LastSigned updates.
+	for number, signer := range d.RecentAdded {
+		next.Recents[number] = signer
+		if number > next.LastSigned[signer] {
+			next.LastSigned[signer] = number
+		}
+	}
+	for _, number := range d.RecentEvicted {
+		delete(next.Recents, number)
+	}
+	for address, tally := range d.TallyUpdated {
+		next.Tally[address] = tally
+	}
+	for _, address := range d.TallyRemoved {
+		delete(next.Tally, address)
+	}
+	if len(d.VotesRemoved) > 0 {
+		removed := make(map[Vote]bool, len(d.VotesRemoved))
+		for _, v := range d.VotesRemoved {
+			removed[*v] = true
+		}
+		kept := next.Votes[:0]
+		for _, v := range next.Votes {
+			if !removed[*v] {
+				kept = append(kept, v)
+			}
+		}
+		next.Votes = kept
+	}
+	next.Votes = append(next.Votes, d.VotesAdded...)
+
+	next.Number = d.Number
+	next.Hash = d.Hash
+	next.EpochHash = d.EpochHash
+	return next
+}
+
+// encodeDelta serializes a SnapshotDelta as a gob stream; gob frames its own
+// messages and the blob is self-describing, so decodeDelta needs no extra
+// framing.
+func encodeDelta(d *SnapshotDelta) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeDelta deserializes a SnapshotDelta previously written by encodeDelta.
+func decodeDelta(blob []byte) (*SnapshotDelta, error) {
+	d := new(SnapshotDelta)
+	if err := gob.NewDecoder(bytes.NewReader(blob)).Decode(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
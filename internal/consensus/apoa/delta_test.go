@@ -0,0 +1,121 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/conf"
+)
+
+// TestDeltaReconstruction verifies that replaying an encoded/decoded
+// SnapshotDelta on top of the parent snapshot reproduces the child snapshot
+// byte-for-byte in its exported fields.
+func TestDeltaReconstruction(t *testing.T) {
+	a, b, c := types.Address{0x01}, types.Address{0x02}, types.Address{0x03}
+
+	prev := &Snapshot{
+		Number:  10,
+		Hash:    types.Hash{0x10},
+		Signers: map[types.Address]struct{}{a: {}, b: {}},
+		Recents: map[uint64]types.Address{9: a, 8: b},
+		Votes:   []*Vote{{Signer: a, Block: 9, Address: c, Authorize: true}},
+		Tally:   map[types.Address]Tally{c: {Authorize: true, Votes: 1}},
+	}
+
+	next := prev.copy()
+	next.Votes = append([]*Vote{}, prev.Votes...)
+	next.Number = 11
+	next.Hash = types.Hash{0x11}
+	delete(next.Recents, 8)
+	next.Recents[10] = a
+	next.Signers[c] = struct{}{}
+	next.Votes = nil
+	next.Tally = map[types.Address]Tally{}
+
+	delta := diffSnapshot(prev, next)
+
+	blob, err := encodeDelta(delta)
+	if err != nil {
+		t.Fatalf("encodeDelta: %v", err)
+	}
+	decoded, err := decodeDelta(blob)
+	if err != nil {
+		t.Fatalf("decodeDelta: %v", err)
+	}
+
+	got := prev.applyDelta(decoded)
+	if got.Number != next.Number || got.Hash != next.Hash {
+		t.Fatalf("header mismatch: got {%d %x}, want {%d %x}", got.Number, got.Hash, next.Number, next.Hash)
+	}
+	if !reflect.DeepEqual(got.Signers, next.Signers) {
+		t.Fatalf("signers mismatch: got %v, want %v", got.Signers, next.Signers)
+	}
+	if !reflect.DeepEqual(got.Recents, next.Recents) {
+		t.Fatalf("recents mismatch: got %v, want %v", got.Recents, next.Recents)
+	}
+	if !reflect.DeepEqual(got.Tally, next.Tally) {
+		t.Fatalf("tally mismatch: got %v, want %v", got.Tally, next.Tally)
+	}
+	if len(got.Votes) != 0 {
+		t.Fatalf("votes mismatch: got %v, want empty", got.Votes)
+	}
+}
+
+// TestDeltaPreservesEpochHashAcrossEpochBoundary verifies that when an epoch
+// boundary block lands on a non-checkpoint number (CheckpointInterval doesn't
+// evenly divide Epoch, so the boundary is persisted as a delta rather than a
+// full snapshot), replaying that delta still reproduces the new EpochHash.
+// Without carrying EpochHash on SnapshotDelta, a node reconstructing from disk
+// would keep the stale EpochHash and could compute a different shuffled
+// scheduler order than a node using the live in-memory snapshot.
+func TestDeltaPreservesEpochHashAcrossEpochBoundary(t *testing.T) {
+	store := &kvSnapshotStore{config: &conf.APoaConfig{Epoch: 10, CheckpointInterval: 3}}
+	if store.isCheckpoint(10) {
+		t.Fatalf("test setup invalid: CheckpointInterval=3 must not evenly divide the Epoch=10 boundary")
+	}
+
+	a := types.Address{0x01}
+	prev := &Snapshot{
+		Number:    9,
+		Hash:      types.Hash{0x09},
+		EpochHash: types.Hash{0xaa},
+		Signers:   map[types.Address]struct{}{a: {}},
+		Recents:   map[uint64]types.Address{},
+		Tally:     map[types.Address]Tally{},
+	}
+	next := prev.copy()
+	next.Number = 10
+	next.Hash = types.Hash{0x10}
+	next.EpochHash = types.Hash{0xbb} // this is the new epoch's checkpoint hash
+
+	blob, err := encodeDelta(diffSnapshot(prev, next))
+	if err != nil {
+		t.Fatalf("encodeDelta: %v", err)
+	}
+	decoded, err := decodeDelta(blob)
+	if err != nil {
+		t.Fatalf("decodeDelta: %v", err)
+	}
+
+	got := prev.applyDelta(decoded)
+	if got.EpochHash != next.EpochHash {
+		t.Fatalf("epoch hash not preserved across delta replay: got %x, want %x", got.EpochHash, next.EpochHash)
+	}
+}
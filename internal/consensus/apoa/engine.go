@@ -0,0 +1,214 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"sync"
+
+	"github.com/amazechain/amc/common/block"
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/conf"
+	"github.com/amazechain/amc/internal/consensus"
+	"github.com/amazechain/amc/internal/rpc"
+	"github.com/amazechain/amc/log"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+)
+
+// Engine is the Proof-of-Authority consensus engine, satisfying the
+// consensus.Engine interface.
+type Engine struct {
+	config *conf.APoaConfig // Consensus engine configuration parameters
+	db     kv.RwDB          // Database to store and retrieve snapshot checkpoints
+	store  SnapshotStore    // Checkpointed snapshot persistence, fronted by a cache
+
+	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+
+	proposals map[types.Address]bool // Current list of proposals we are pushing
+
+	lock sync.RWMutex  // Protects the signer fields
+	quit chan struct{} // Closed to stop the background pruner
+}
+
+// New creates a APoa proof-of-authority consensus engine with the initial
+// signers set to the ones provided by the user.
+func New(config *conf.APoaConfig, db kv.RwDB) *Engine {
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	signatures, _ := lru.NewARC(inmemorySignatures)
+
+	e := &Engine{
+		config:     config,
+		db:         db,
+		store:      newSnapshotStore(config, signatures, inmemorySnapshots),
+		recents:    recents,
+		signatures: signatures,
+		proposals:  make(map[types.Address]bool),
+		quit:       make(chan struct{}),
+	}
+	if config.RetentionBlocks > 0 {
+		go e.runPruner(e.quit)
+	}
+	return e
+}
+
+// Close stops the engine's background pruner.
+func (e *Engine) Close() error {
+	close(e.quit)
+	return nil
+}
+
+// Author implements consensus.Engine, returning the Ethereum address recovered
+// from the signature in the header's extra-data section.
+func (e *Engine) Author(header *block.Header) (types.Address, error) {
+	return ecrecover(header, e.signatures)
+}
+
+// snapshot retrieves the authorization snapshot at a given point in time.
+func (e *Engine) snapshot(chain consensus.ChainHeaderReader, number uint64, hash types.Hash, parents []block.IHeader) (*Snapshot, error) {
+	var (
+		headers []block.IHeader
+		snap    *Snapshot
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := e.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint (or checkpoint+deltas) snapshot can be
+		// found, use that
+		if tx, err := e.db.BeginRo(nil); err == nil {
+			s, err := e.store.GetByNumber(tx, number)
+			tx.Rollback()
+			if err == nil && s.Hash == hash {
+				log.Debug("Loaded voting snapshot from disk", "number", number, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at the genesis, snapshot the initial state. The genesis
+		// header may not be among the headers collected so far (e.g. when
+		// snapshot is asked for block 0 directly, headers is still empty),
+		// so fetch it from the chain rather than indexing into that slice.
+		if number == 0 {
+			genesis := chain.GetHeaderByNumber(0)
+			if genesis == nil {
+				return nil, errUnknownBlock
+			}
+			signers := make([]types.Address, 0)
+			snap = newSnapshot(e.config, e.signatures, 0, genesis.Hash(), signers)
+			break
+		}
+		// No snapshot for this header, gather the header and move backward
+		var header block.IHeader
+		if len(parents) > 0 {
+			// If we have explicit parents, pick from there (enforced)
+			header = parents[len(parents)-1]
+			parents = parents[:len(parents)-1]
+		} else {
+			// No explicit parents (or no more left), reach out to the database
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, errUnknownBlock
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash()
+	}
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	base := snap
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	e.recents.Add(snap.Hash, snap)
+
+	if len(headers) > 0 {
+		if tx, err := e.db.BeginRw(nil); err == nil {
+			if err := e.store.Put(tx, base, snap); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of
+// the header for running the transactions on top.
+func (e *Engine) Prepare(chain consensus.ChainHeaderReader, header *block.Header) error {
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return errUnknownBlock
+	}
+	snap, err := e.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+
+	// If a pending proposal from the console is still valid, push it first.
+	if header.Coinbase == (types.Address{}) {
+		e.lock.RLock()
+		for address, authorize := range e.proposals {
+			if snap.validVote(address, authorize) {
+				header.Coinbase = address
+				if authorize {
+					copy(header.Nonce[:], nonceAuthVote)
+				} else {
+					copy(header.Nonce[:], nonceDropVote)
+				}
+				break
+			}
+		}
+		e.lock.RUnlock()
+	}
+
+	// If auto-kick is enabled and nothing else has already proposed a vote
+	// for this block, stuff a deauthorization vote against the first signer
+	// that has gone quiet for longer than the configured threshold.
+	if header.Coinbase == (types.Address{}) && e.config.AutoKickEnabled {
+		if target, ok := snap.firstInactiveSigner(number, e.config.InactivityThreshold); ok {
+			header.Coinbase = target
+			copy(header.Nonce[:], nonceDropVote)
+		}
+	}
+	return nil
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to allow
+// controlling the signer voting.
+func (e *Engine) APIs(chain consensus.ChainHeaderReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "apoa",
+		Service:   &API{chain: chain, apoa: e},
+	}}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"testing"
+
+	"github.com/amazechain/amc/common/types"
+)
+
+// TestFirstInactiveSignerKicksAfterThreshold verifies that a signer who has
+// gone silent for more than the configured InactivityThreshold is surfaced
+// for an automatic deauthorization vote, and that an active signer is not.
+func TestFirstInactiveSignerKicksAfterThreshold(t *testing.T) {
+	a, b, c := types.Address{0x01}, types.Address{0x02}, types.Address{0x03}
+
+	snap := &Snapshot{
+		Number:  1000,
+		Signers: map[types.Address]struct{}{a: {}, b: {}, c: {}},
+		Tally:   map[types.Address]Tally{},
+		LastSigned: map[types.Address]uint64{
+			a: 990, // active, within the window
+			b: 900, // quiet for 100 blocks
+			// c has never signed, so it is never flagged
+		},
+	}
+
+	if _, ok := snap.firstInactiveSigner(1000, 0); ok {
+		t.Fatalf("threshold 0 must disable auto-kick")
+	}
+	if _, ok := snap.firstInactiveSigner(1000, 200); ok {
+		t.Fatalf("no signer should be flagged below the threshold")
+	}
+
+	target, ok := snap.firstInactiveSigner(1000, 50)
+	if !ok {
+		t.Fatalf("expected an inactive signer to be found")
+	}
+	if target != b {
+		t.Fatalf("expected %x to be flagged inactive, got %x", b, target)
+	}
+}
+
+// TestFirstInactiveSignerKeepsReportingUntilResolved verifies that
+// firstInactiveSigner has no memory of votes already cast: an inactive signer
+// with a standing kick vote already in the tally is reported again on every
+// call, exactly like an active proposal is re-stuffed into every block until
+// it passes or is discarded. The one-vote-per-target rule is enforced by
+// apply (which dedups by signer, not by caller), not by firstInactiveSigner.
+func TestFirstInactiveSignerKeepsReportingUntilResolved(t *testing.T) {
+	a, b := types.Address{0x01}, types.Address{0x02}
+
+	snap := &Snapshot{
+		Number:  1000,
+		Signers: map[types.Address]struct{}{a: {}, b: {}},
+		// b already has an outstanding deauthorization vote recorded.
+		Tally:      map[types.Address]Tally{b: {Authorize: false, Votes: 1}},
+		LastSigned: map[types.Address]uint64{a: 990, b: 100},
+	}
+
+	for i := 0; i < 2; i++ {
+		target, ok := snap.firstInactiveSigner(1000, 50)
+		if !ok {
+			t.Fatalf("call %d: expected %x to still be reported inactive", i, b)
+		}
+		if target != b {
+			t.Fatalf("call %d: expected %x, got %x", i, b, target)
+		}
+	}
+}
+
+// TestFirstInactiveSignerSkipsNonSigners verifies that an address which is no
+// longer part of the authorized signer set is never offered for
+// deauthorization, since validVote rejects voting to remove a non-signer.
+func TestFirstInactiveSignerSkipsNonSigners(t *testing.T) {
+	a, b := types.Address{0x01}, types.Address{0x02}
+
+	snap := &Snapshot{
+		Number:     1000,
+		Signers:    map[types.Address]struct{}{a: {}},
+		Tally:      map[types.Address]Tally{},
+		LastSigned: map[types.Address]uint64{a: 990, b: 100},
+	}
+
+	if _, ok := snap.firstInactiveSigner(1000, 50); ok {
+		t.Fatalf("a non-signer must never be offered for deauthorization")
+	}
+}
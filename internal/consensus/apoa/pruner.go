@@ -0,0 +1,86 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"time"
+
+	"github.com/amazechain/amc/log"
+)
+
+// pruneInterval is how often the background pruner wakes up to check whether
+// any snapshots have fallen out of the retention window.
+const pruneInterval = time.Minute
+
+// runPruner periodically removes persisted snapshots older than the
+// configured retention window. It is started once from New when
+// config.RetentionBlocks is non-zero, and exits when stop is closed.
+func (e *Engine) runPruner(stop chan struct{}) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			latest, err := e.latestSnapshotNumber()
+			if err != nil {
+				log.Warn("Failed to determine latest apoa snapshot number", "err", err)
+				continue
+			}
+			if latest <= e.config.RetentionBlocks {
+				continue
+			}
+			keepAbove := latest - e.config.RetentionBlocks
+
+			tx, err := e.db.BeginRw(nil)
+			if err != nil {
+				log.Warn("Failed to open apoa pruner transaction", "err", err)
+				continue
+			}
+			removed, err := e.store.Prune(tx, keepAbove)
+			if err != nil {
+				tx.Rollback()
+				log.Warn("Failed to prune apoa snapshots", "err", err)
+				continue
+			}
+			if err := tx.Commit(); err != nil {
+				log.Warn("Failed to commit apoa snapshot prune", "err", err)
+				continue
+			}
+			if removed > 0 {
+				log.Debug("Pruned apoa snapshots", "removed", removed, "keepAbove", keepAbove)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// latestSnapshotNumber returns the highest block number among the cached
+// in-memory snapshots, used as the pruner's reference point for the retention
+// window.
+func (e *Engine) latestSnapshotNumber() (uint64, error) {
+	var latest uint64
+	for _, key := range e.recents.Keys() {
+		if s, ok := e.recents.Peek(key); ok {
+			if snap := s.(*Snapshot); snap.Number > latest {
+				latest = snap.Number
+			}
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"encoding/binary"
+
+	"github.com/amazechain/amc/common/types"
+)
+
+// Scheduler decides which authorized signer is in-turn for a given block
+// number, and the relative priority (0..N-1, 0 being in-turn) of every other
+// signer. Priority drives the in-turn check (Snapshot.inturn); nothing in this
+// package computes an out-of-turn wiggle delay from it. A Scheduler only needs
+// to define one thing: an ordering of the signer set for a given block.
+type Scheduler interface {
+	// order returns signers permuted into the order they are expected to
+	// seal in, for the epoch containing number. epochHash is the hash of the
+	// checkpoint block that opened that epoch.
+	order(number uint64, epochHash types.Hash, signers []types.Address) []types.Address
+}
+
+// roundRobinScheduler is the original APoa scheduling rule: signers seal in
+// the fixed ascending-address order returned by Snapshot.signers, forever.
+type roundRobinScheduler struct{}
+
+func (roundRobinScheduler) order(_ uint64, _ types.Hash, signers []types.Address) []types.Address {
+	return signers
+}
+
+// shuffledEpochScheduler reorders the signer set once per epoch, seeding a
+// deterministic Fisher-Yates permutation from the epoch's checkpoint hash so
+// every node derives the same order without any extra consensus state.
+type shuffledEpochScheduler struct{}
+
+func (shuffledEpochScheduler) order(_ uint64, epochHash types.Hash, signers []types.Address) []types.Address {
+	shuffled := make([]types.Address, len(signers))
+	copy(shuffled, signers)
+
+	seed := binary.BigEndian.Uint64(epochHash[:8])
+	rnd := newSplitMix64(seed)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := int(rnd.next() % uint64(i+1))
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled
+}
+
+// schedulerFor resolves a conf.APoaConfig.SchedulerKind value to its
+// Scheduler implementation, defaulting to the original round-robin order when
+// the kind is unset or unrecognized.
+func schedulerFor(kind string) Scheduler {
+	switch kind {
+	case "shuffled-epoch":
+		return shuffledEpochScheduler{}
+	default:
+		return roundRobinScheduler{}
+	}
+}
+
+// splitMix64 is a small, fast, deterministic PRNG used only to turn an epoch
+// hash into a reproducible permutation; it has no cryptographic properties
+// and must not be used for anything security sensitive.
+type splitMix64 struct{ state uint64 }
+
+func newSplitMix64(seed uint64) *splitMix64 { return &splitMix64{state: seed} }
+
+func (r *splitMix64) next() uint64 {
+	r.state += 0x9E3779B97F4A7C15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"github.com/amazechain/amc/common/block"
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/internal/avm/crypto"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// extraSeal is the fixed number of extra-data suffix bytes reserved for the
+// signer's signature.
+const extraSeal = 65
+
+var (
+	// nonceAuthVote is the magic nonce number to vote on adding a new signer.
+	nonceAuthVote = []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	// nonceDropVote is the magic nonce number to vote on removing a signer.
+	nonceDropVote = []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+)
+
+// sigHash returns the hash which is used as input for the proof-of-authority
+// signing. It is the hash of the header, but with the signature part of the
+// extra-data removed.
+func sigHash(header *block.Header) types.Hash {
+	b := header.Extra
+	header.Extra = header.Extra[:len(header.Extra)-extraSeal]
+	defer func() { header.Extra = b }()
+
+	return header.SealHash()
+}
+
+// ecrecover extracts the Ethereum account address from a signed header.
+func ecrecover(header *block.Header, sigcache *lru.ARCCache) (types.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(types.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	if len(header.Extra) < extraSeal {
+		return types.Address{}, errMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	if err != nil {
+		return types.Address{}, err
+	}
+	var signer types.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
@@ -57,12 +57,14 @@ type Snapshot struct {
 	config   *conf.APoaConfig // Consensus engine parameters to fine tune behavior
 	sigcache *lru.ARCCache    // Cache of recent block signatures to speed up ecrecover
 
-	Number  uint64                     `json:"number"`  // Block number where the snapshot was created
-	Hash    types.Hash                 `json:"hash"`    // Block hash where the snapshot was created
-	Signers map[types.Address]struct{} `json:"signers"` // Set of authorized signers at this moment
-	Recents map[uint64]types.Address   `json:"recents"` // Set of recent signers for spam protections   The address of the signer of the most recent block
-	Votes   []*Vote                    `json:"votes"`   // List of votes cast in chronological order
-	Tally   map[types.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
+	Number     uint64                     `json:"number"`     // Block number where the snapshot was created
+	Hash       types.Hash                 `json:"hash"`       // Block hash where the snapshot was created
+	Signers    map[types.Address]struct{} `json:"signers"`    // Set of authorized signers at this moment
+	Recents    map[uint64]types.Address   `json:"recents"`    // Set of recent signers for spam protections   The address of the signer of the most recent block
+	Votes      []*Vote                    `json:"votes"`      // List of votes cast in chronological order
+	Tally      map[types.Address]Tally    `json:"tally"`      // Current vote tally to avoid recalculating
+	EpochHash  types.Hash                 `json:"epochHash"`  // Hash of the checkpoint block that opened the current epoch
+	LastSigned map[types.Address]uint64   `json:"lastSigned"` // Block number of each signer's most recent successful seal
 }
 
 // signersAscending implements the sort interface to allow sorting a list of addresses
@@ -77,13 +79,14 @@ func (s signersAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 // the genesis block.
 func newSnapshot(config *conf.APoaConfig, sigcache *lru.ARCCache, number uint64, hash types.Hash, signers []types.Address) *Snapshot {
 	snap := &Snapshot{
-		config:   config,
-		sigcache: sigcache,
-		Number:   number,
-		Hash:     hash,
-		Signers:  make(map[types.Address]struct{}),
-		Recents:  make(map[uint64]types.Address),
-		Tally:    make(map[types.Address]Tally),
+		config:     config,
+		sigcache:   sigcache,
+		Number:     number,
+		Hash:       hash,
+		Signers:    make(map[types.Address]struct{}),
+		Recents:    make(map[uint64]types.Address),
+		Tally:      make(map[types.Address]Tally),
+		LastSigned: make(map[types.Address]uint64),
 	}
 	// Iterate over the list of Signers and store the signers address in the Signers map of the Snapshot object.
 	// Use struct{} to assign a unique flag to the address for use in statistics.
@@ -109,29 +112,19 @@ func loadSnapshot(config *conf.APoaConfig, sigcache *lru.ARCCache, tx kv.Getter,
 	return snap, nil
 }
 
-// store inserts the snapshot into the database.
-func (s *Snapshot) store(tx kv.Putter) error { //The parameter is a pointer to Snapshot
-	blob, err := json.Marshal(s) //Convert s (snapshot object) to JSON format and store it as a blob object
-	if err != nil {
-		return err
-	}
-
-	// Writes the blob object to the snapshot database, where s.ash is the hash of the snapshot object.
-	// Returns nil if  succeeded, otherwise returns an error object
-	return rawdb.StorePoaSnapshot(tx, s.Hash, blob)
-}
-
 // copy creates a deep copy of the snapshot, though not the individual votes.
 func (s *Snapshot) copy() *Snapshot {
 	cpy := &Snapshot{ // Example Create snapshot object cpy
-		config:   s.config,
-		sigcache: s.sigcache,
-		Number:   s.Number,
-		Hash:     s.Hash,
-		Signers:  make(map[types.Address]struct{}),
-		Recents:  make(map[uint64]types.Address),
-		Votes:    make([]*Vote, len(s.Votes)),
-		Tally:    make(map[types.Address]Tally),
+		config:     s.config,
+		sigcache:   s.sigcache,
+		Number:     s.Number,
+		Hash:       s.Hash,
+		Signers:    make(map[types.Address]struct{}),
+		Recents:    make(map[uint64]types.Address),
+		Votes:      make([]*Vote, len(s.Votes)),
+		Tally:      make(map[types.Address]Tally),
+		EpochHash:  s.EpochHash,
+		LastSigned: make(map[types.Address]uint64),
 	}
 	// Iterate over the elements in s.Signers, s.Revents, and s.Tally and add them to the corresponding mapping of the new object cpy
 	for signer := range s.Signers {
@@ -143,6 +136,9 @@ func (s *Snapshot) copy() *Snapshot {
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
+	for address, number := range s.LastSigned {
+		cpy.LastSigned[address] = number
+	}
 	copy(cpy.Votes, s.Votes) // Copy the elements from s.Votes to cpy.Votes
 
 	return cpy
@@ -194,6 +190,24 @@ func (s *Snapshot) uncast(address types.Address, authorize bool) bool {
 	return true
 }
 
+// sweepExpiredVotes removes any vote older than config.VoteTTL blocks as of
+// number, decrementing its tally via uncast. A VoteTTL of 0 disables the
+// sweep entirely, preserving the legacy behavior of only clearing votes on
+// epoch boundaries.
+func (s *Snapshot) sweepExpiredVotes(number uint64) {
+	if s.config.VoteTTL == 0 {
+		return
+	}
+	for i := 0; i < len(s.Votes); i++ {
+		vote := s.Votes[i]
+		if number > vote.Block && number-vote.Block > s.config.VoteTTL {
+			s.uncast(vote.Address, vote.Authorize)
+			s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+			i--
+		}
+	}
+}
+
 // apply creates a new authorization snapshot by applying the given headers to
 // the original one.
 // apply takes the block headers as input, counts all voting information for those block headers,
@@ -226,6 +240,13 @@ func (s *Snapshot) apply(headers []block.IHeader) (*Snapshot, error) {
 		if number%s.config.Epoch == 0 {
 			snap.Votes = nil
 			snap.Tally = make(map[types.Address]Tally)
+			snap.EpochHash = header.Hash()
+		} else {
+			// Epoch resets already clear every vote above, so TTL expiry only
+			// needs to run in between: sweep out votes older than VoteTTL
+			// blocks so a stale vote cast early in an epoch can't linger for
+			// the remainder of it.
+			snap.sweepExpiredVotes(number)
 		}
 		// Delete the oldest signer from the recent list to allow it signing again
 		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
@@ -245,6 +266,7 @@ func (s *Snapshot) apply(headers []block.IHeader) (*Snapshot, error) {
 			}
 		}
 		snap.Recents[number] = signer
+		snap.LastSigned[signer] = number
 
 		// Header authorized, discard any previous votes from the signer
 		// Ensure that a signer within an epoch can vote only once
@@ -335,12 +357,53 @@ func (s *Snapshot) signers() []types.Address {
 	return sigs
 }
 
+// signerPriority returns signer's rank (0..N-1) for block number under the
+// configured Scheduler, where 0 means in-turn for that exact block and every
+// other signer's rank is its distance behind the in-turn slot. An address
+// outside the signer set gets N, and an empty signer set gives 0. inturn is
+// the only reader of the rank; no wiggle delay is derived from it here.
+func (s *Snapshot) signerPriority(number uint64, signer types.Address) int {
+	order := schedulerFor(s.config.SchedulerKind).order(number, s.EpochHash, s.signers())
+	if len(order) == 0 {
+		return 0
+	}
+	index := -1
+	for i, addr := range order {
+		if addr == signer {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return len(order)
+	}
+	inTurn := int(number % uint64(len(order)))
+	return (index - inTurn + len(order)) % len(order)
+}
+
 // inturn returns if a signer at a given block height is in-turn or not.
 // intern by determining whether the height of the current block is in the same order as it is in the signer list
 func (s *Snapshot) inturn(number uint64, signer types.Address) bool {
-	signers, offset := s.signers(), 0
-	for offset < len(signers) && signers[offset] != signer {
-		offset++
+	return s.signerPriority(number, signer) == 0
+}
+
+// firstInactiveSigner returns, in ascending address order, the first
+// authorized signer whose most recent successful seal is more than threshold
+// blocks behind number. Signers with no recorded seal in LastSigned are
+// skipped, so they are never reported. ok is false if threshold is 0 (the
+// feature disabled) or every signer is within the window.
+func (s *Snapshot) firstInactiveSigner(number, threshold uint64) (signer types.Address, ok bool) {
+	if threshold == 0 {
+		return types.Address{}, false
+	}
+	for _, addr := range s.signers() {
+		last, signed := s.LastSigned[addr]
+		if !signed || number < last {
+			continue
+		}
+		if number-last > threshold && s.validVote(addr, false) {
+			return addr, true
+		}
 	}
-	return (number % uint64(len(signers))) == uint64(offset)
+	return types.Address{}, false
 }
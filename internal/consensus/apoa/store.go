@@ -0,0 +1,200 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/conf"
+	"github.com/amazechain/amc/modules/rawdb"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// storeMetrics accumulates cheap, in-process counters for the snapshot store.
+// They are surfaced through the apoa API rather than a metrics registry since
+// the engine otherwise has no dependency on one.
+type storeMetrics struct {
+	CacheHits   uint64 `json:"cacheHits"`
+	CacheMisses uint64 `json:"cacheMisses"`
+	StoreWrites uint64 `json:"storeWrites"`
+	Pruned      uint64 `json:"pruned"`
+
+	// StoreSize is the number of checkpoints and deltas this store has
+	// written and not yet pruned: every Put increments it by one, every Prune
+	// decrements it by the number of entries it removed, stopping at zero.
+	// Entries already on disk when the store was created are not counted.
+	StoreSize uint64 `json:"storeSize"`
+}
+
+// SnapshotStore persists and retrieves authorization snapshots. Full
+// snapshots are only written at checkpoint boundaries; in between, a compact
+// SnapshotDelta is written per block and replayed forward from the nearest
+// checkpoint on read.
+type SnapshotStore interface {
+	// GetByNumber reconstructs the snapshot at number, loading the nearest
+	// prior full checkpoint and replaying deltas forward on top of it.
+	GetByNumber(tx kv.Getter, number uint64) (*Snapshot, error)
+	// Put persists the transition from prev to snap: a full snapshot at a
+	// checkpoint boundary or when prev is not snap's direct parent, or a
+	// SnapshotDelta otherwise. prev may be nil only when snap is the genesis
+	// snapshot.
+	Put(tx kv.RwTx, prev, snap *Snapshot) error
+	// Prune deletes every indexed checkpoint and delta whose block number is
+	// below keepAbove, returning the number of entries removed.
+	Prune(tx kv.RwTx, keepAbove uint64) (int, error)
+	// Metrics returns a point-in-time snapshot of the store's counters.
+	Metrics() storeMetrics
+}
+
+// kvSnapshotStore is the default SnapshotStore, backed by the chain database
+// and fronted by an ARC cache so repeated reads of hot snapshots (e.g. while
+// verifying a run of headers) don't round-trip the database.
+type kvSnapshotStore struct {
+	config   *conf.APoaConfig
+	sigcache *lru.ARCCache
+	cache    *lru.ARCCache // block number -> *Snapshot
+
+	hits, misses, writes, pruned, size uint64
+}
+
+// newSnapshotStore creates the default KV-backed SnapshotStore with an
+// in-memory ARC cache layer of the given size in front of it.
+func newSnapshotStore(config *conf.APoaConfig, sigcache *lru.ARCCache, cacheSize int) *kvSnapshotStore {
+	cache, _ := lru.NewARC(cacheSize)
+	return &kvSnapshotStore{
+		config:   config,
+		sigcache: sigcache,
+		cache:    cache,
+	}
+}
+
+// GetByNumber implements SnapshotStore.
+func (store *kvSnapshotStore) GetByNumber(tx kv.Getter, number uint64) (*Snapshot, error) {
+	if s, ok := store.cache.Get(number); ok {
+		atomic.AddUint64(&store.hits, 1)
+		return s.(*Snapshot), nil
+	}
+	atomic.AddUint64(&store.misses, 1)
+
+	checkpoint, hash, err := rawdb.GetPoaCheckpointAtOrBefore(tx, number)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := loadSnapshot(store.config, store.sigcache, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	for n := checkpoint + 1; n <= number; n++ {
+		blob, err := rawdb.GetPoaSnapshotDelta(tx, n)
+		if err != nil {
+			return nil, err
+		}
+		delta, err := decodeDelta(blob)
+		if err != nil {
+			return nil, err
+		}
+		snap = snap.applyDelta(delta)
+	}
+	store.cache.Add(number, snap)
+	return snap, nil
+}
+
+// Put implements SnapshotStore. It writes a full snapshot through to disk
+// when snap falls on a checkpoint boundary (a multiple of
+// config.CheckpointInterval when that is configured to something tighter than
+// the epoch length, a multiple of config.Epoch otherwise), or when prev is nil
+// or not snap's direct parent; otherwise it writes the much smaller delta
+// between prev and snap.
+func (store *kvSnapshotStore) Put(tx kv.RwTx, prev, snap *Snapshot) error {
+	store.cache.Add(snap.Number, snap)
+
+	if store.isCheckpoint(snap.Number) || prev == nil || snap.Number != prev.Number+1 {
+		blob, err := json.Marshal(snap)
+		if err != nil {
+			return err
+		}
+		if err := rawdb.StorePoaSnapshot(tx, snap.Hash, blob); err != nil {
+			return err
+		}
+		if err := rawdb.StorePoaCheckpointNumHash(tx, snap.Number, snap.Hash); err != nil {
+			return err
+		}
+		atomic.AddUint64(&store.writes, 1)
+		atomic.AddUint64(&store.size, 1)
+		return nil
+	}
+
+	blob, err := encodeDelta(diffSnapshot(prev, snap))
+	if err != nil {
+		return err
+	}
+	if err := rawdb.StorePoaSnapshotDelta(tx, snap.Number, blob); err != nil {
+		return err
+	}
+	atomic.AddUint64(&store.writes, 1)
+	atomic.AddUint64(&store.size, 1)
+	return nil
+}
+
+// Prune implements SnapshotStore. It asks the database for every indexed
+// checkpoint and delta below keepAbove, removes them, and evicts any that
+// happen to still be cached.
+func (store *kvSnapshotStore) Prune(tx kv.RwTx, keepAbove uint64) (int, error) {
+	removed, err := rawdb.PrunePoaSnapshotsBelow(tx, keepAbove, func(number uint64) {
+		store.cache.Remove(number)
+	})
+	if err != nil {
+		return 0, err
+	}
+	atomic.AddUint64(&store.pruned, uint64(removed))
+	for {
+		size := atomic.LoadUint64(&store.size)
+		next := uint64(0)
+		if size > uint64(removed) {
+			next = size - uint64(removed)
+		}
+		if atomic.CompareAndSwapUint64(&store.size, size, next) {
+			break
+		}
+	}
+	return removed, nil
+}
+
+// Metrics implements SnapshotStore.
+func (store *kvSnapshotStore) Metrics() storeMetrics {
+	return storeMetrics{
+		CacheHits:   atomic.LoadUint64(&store.hits),
+		CacheMisses: atomic.LoadUint64(&store.misses),
+		StoreWrites: atomic.LoadUint64(&store.writes),
+		Pruned:      atomic.LoadUint64(&store.pruned),
+		StoreSize:   atomic.LoadUint64(&store.size),
+	}
+}
+
+// isCheckpoint reports whether number is a boundary at which a full snapshot
+// should be flushed to disk.
+func (store *kvSnapshotStore) isCheckpoint(number uint64) bool {
+	interval := store.config.Epoch
+	if store.config.CheckpointInterval > 0 && store.config.CheckpointInterval < interval {
+		interval = store.config.CheckpointInterval
+	}
+	return interval != 0 && number%interval == 0
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package apoa
+
+import (
+	"testing"
+
+	"github.com/amazechain/amc/common/types"
+	"github.com/amazechain/amc/conf"
+)
+
+// TestSweepExpiredVotesDisabledByDefault verifies that a VoteTTL of 0 leaves
+// votes untouched, preserving the legacy epoch-only reset behavior.
+func TestSweepExpiredVotesDisabledByDefault(t *testing.T) {
+	signer, target := types.Address{0x01}, types.Address{0x02}
+	snap := &Snapshot{
+		config: &conf.APoaConfig{Epoch: 30000},
+		Votes:  []*Vote{{Signer: signer, Block: 1, Address: target, Authorize: true}},
+		Tally:  map[types.Address]Tally{target: {Authorize: true, Votes: 1}},
+	}
+
+	snap.sweepExpiredVotes(100000)
+
+	if len(snap.Votes) != 1 {
+		t.Fatalf("expected the vote to survive with VoteTTL disabled, got %d votes", len(snap.Votes))
+	}
+}
+
+// TestSweepExpiredVotesMidEpoch verifies that a vote cast near the start of
+// an epoch expires once VoteTTL blocks have passed, even though the epoch
+// reset that would otherwise clear it is still thousands of blocks away.
+func TestSweepExpiredVotesMidEpoch(t *testing.T) {
+	signerA, signerB, target := types.Address{0x01}, types.Address{0x02}, types.Address{0x03}
+	snap := &Snapshot{
+		config: &conf.APoaConfig{Epoch: 30000, VoteTTL: 100},
+		Votes: []*Vote{
+			{Signer: signerA, Block: 1, Address: target, Authorize: true},
+		},
+		Tally: map[types.Address]Tally{target: {Authorize: true, Votes: 1}},
+	}
+
+	// Still within the TTL window: nothing should be swept.
+	snap.sweepExpiredVotes(50)
+	if len(snap.Votes) != 1 {
+		t.Fatalf("vote expired too early: %d votes left", len(snap.Votes))
+	}
+
+	// A second signer votes for the same target before the first vote
+	// expires; the tally should reach 2 without the stale vote being purged
+	// prematurely.
+	if !snap.cast(target, true) {
+		t.Fatalf("expected second vote to be cast")
+	}
+	snap.Votes = append(snap.Votes, &Vote{Signer: signerB, Block: 50, Address: target, Authorize: true})
+
+	// Once the first vote is more than VoteTTL blocks old, it must be swept
+	// and its contribution to the tally removed, even mid-epoch.
+	snap.sweepExpiredVotes(150)
+	if len(snap.Votes) != 1 {
+		t.Fatalf("expected exactly one surviving vote, got %d", len(snap.Votes))
+	}
+	if tally := snap.Tally[target]; tally.Votes != 1 {
+		t.Fatalf("expected tally to drop back to 1 after expiry, got %d", tally.Votes)
+	}
+}
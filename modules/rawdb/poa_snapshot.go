@@ -0,0 +1,138 @@
+// Copyright 2022 The AmazeChain Authors
+// This file is part of the AmazeChain library.
+//
+// The AmazeChain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The AmazeChain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the AmazeChain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/amazechain/amc/common/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// ErrPoaSnapshotNotFound is returned when no apoa checkpoint is indexed at or
+// before the requested block number.
+var ErrPoaSnapshotNotFound = errors.New("apoa: no snapshot checkpoint found")
+
+// Tables holding apoa snapshot state. PoaSnapshots holds full, JSON-encoded
+// snapshots keyed by block hash. PoaCheckpoints indexes those full snapshots
+// by block number so the nearest one at or before a target number can be
+// found without scanning PoaSnapshots. PoaSnapshotDeltas holds the much
+// smaller gob-encoded SnapshotDelta for every block in between checkpoints.
+const (
+	PoaSnapshots      = "PoaSnapshots"
+	PoaCheckpoints    = "PoaCheckpoints"
+	PoaSnapshotDeltas = "PoaSnapshotDeltas"
+)
+
+// encodeNumber encodes number as an 8-byte big-endian key, so that
+// lexicographic key order matches numeric order for range scans.
+func encodeNumber(number uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, number)
+	return key
+}
+
+// GetPoaSnapshot returns the full JSON-encoded snapshot blob stored for hash.
+func GetPoaSnapshot(tx kv.Getter, hash types.Hash) ([]byte, error) {
+	return tx.GetOne(PoaSnapshots, hash.Bytes())
+}
+
+// StorePoaSnapshot persists the full JSON-encoded snapshot blob for hash.
+func StorePoaSnapshot(tx kv.Putter, hash types.Hash, blob []byte) error {
+	return tx.Put(PoaSnapshots, hash.Bytes(), blob)
+}
+
+// StorePoaCheckpointNumHash indexes a full snapshot stored at hash under its
+// block number, so it can later be found by GetPoaCheckpointAtOrBefore.
+func StorePoaCheckpointNumHash(tx kv.Putter, number uint64, hash types.Hash) error {
+	return tx.Put(PoaCheckpoints, encodeNumber(number), hash.Bytes())
+}
+
+// GetPoaCheckpointAtOrBefore returns the block number and hash of the latest
+// indexed full snapshot at or before number.
+func GetPoaCheckpointAtOrBefore(tx kv.Getter, number uint64) (uint64, types.Hash, error) {
+	var (
+		bestNumber uint64
+		bestHash   types.Hash
+		found      bool
+	)
+	if err := tx.ForEach(PoaCheckpoints, nil, func(k, v []byte) error {
+		n := binary.BigEndian.Uint64(k)
+		if n > number {
+			return nil
+		}
+		if !found || n > bestNumber {
+			bestNumber = n
+			bestHash = types.BytesToHash(v)
+			found = true
+		}
+		return nil
+	}); err != nil {
+		return 0, types.Hash{}, err
+	}
+	if !found {
+		return 0, types.Hash{}, ErrPoaSnapshotNotFound
+	}
+	return bestNumber, bestHash, nil
+}
+
+// GetPoaSnapshotDelta returns the gob-encoded SnapshotDelta stored for number.
+func GetPoaSnapshotDelta(tx kv.Getter, number uint64) ([]byte, error) {
+	return tx.GetOne(PoaSnapshotDeltas, encodeNumber(number))
+}
+
+// StorePoaSnapshotDelta persists the gob-encoded SnapshotDelta for number.
+func StorePoaSnapshotDelta(tx kv.Putter, number uint64, blob []byte) error {
+	return tx.Put(PoaSnapshotDeltas, encodeNumber(number), blob)
+}
+
+// PrunePoaSnapshotsBelow deletes every indexed checkpoint and delta whose
+// block number is below keepAbove, together with the full snapshot each
+// removed checkpoint points at, invoking onRemove with the number of each
+// entry removed so the caller can evict it from any in-memory cache. It
+// returns the total number of checkpoint and delta entries removed.
+func PrunePoaSnapshotsBelow(tx kv.RwTx, keepAbove uint64, onRemove func(number uint64)) (int, error) {
+	removed := 0
+	for _, table := range [...]string{PoaCheckpoints, PoaSnapshotDeltas} {
+		var stale, staleHashes [][]byte
+		if err := tx.ForEach(table, nil, func(k, v []byte) error {
+			if binary.BigEndian.Uint64(k) < keepAbove {
+				stale = append(stale, append([]byte{}, k...))
+				staleHashes = append(staleHashes, append([]byte{}, v...))
+			}
+			return nil
+		}); err != nil {
+			return removed, err
+		}
+		for i, k := range stale {
+			if err := tx.Delete(table, k); err != nil {
+				return removed, err
+			}
+			if table == PoaCheckpoints {
+				if err := tx.Delete(PoaSnapshots, staleHashes[i]); err != nil {
+					return removed, err
+				}
+			}
+			if onRemove != nil {
+				onRemove(binary.BigEndian.Uint64(k))
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}